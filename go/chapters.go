@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Chapter is one chapter marker read from the input's container metadata
+type Chapter struct {
+	Index        int
+	Title        string
+	StartSeconds float64
+	EndSeconds   float64
+}
+
+// ffprobeChapters mirrors `ffprobe -show_chapters -of json` output
+type ffprobeChapters struct {
+	Chapters []struct {
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+		Tags      struct {
+			Title string `json:"title"`
+		} `json:"tags"`
+	} `json:"chapters"`
+}
+
+// probeChapters lists the chapters embedded in inputPath, if any
+func probeChapters(ffprobePath, inputPath string) ([]Chapter, error) {
+	cmd := exec.Command(ffprobePath, "-v", "error", "-show_chapters", "-of", "json", inputPath)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %v", err)
+	}
+
+	var parsed ffprobeChapters
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing ffprobe output: %v", err)
+	}
+
+	chapters := make([]Chapter, 0, len(parsed.Chapters))
+	for i, ch := range parsed.Chapters {
+		start, _ := strconv.ParseFloat(ch.StartTime, 64)
+		end, _ := strconv.ParseFloat(ch.EndTime, 64)
+		chapters = append(chapters, Chapter{
+			Index:        i,
+			Title:        ch.Tags.Title,
+			StartSeconds: start,
+			EndSeconds:   end,
+		})
+	}
+
+	return chapters, nil
+}
+
+// ffmpegCodecToProbeCodec maps an EncoderProfile's ffmpeg encoder name to the
+// codec_name ffprobe reports for a stream already encoded with it, so
+// splitByChapters can detect when the source audio is already in the target
+// codec and a stream copy is possible instead of a re-encode
+var ffmpegCodecToProbeCodec = map[string]string{
+	"libmp3lame":        "mp3",
+	"libopus":           "opus",
+	"aac":               "aac",
+	"flac":              "flac",
+	"pcm_s16le":         "pcm_s16le",
+	"libopencore_amrnb": "amr_nb",
+}
+
+// canStreamCopy reports whether sourceCodec (as reported by ffprobe) already
+// matches what profile would encode to, making a lossless -c copy possible
+func canStreamCopy(profile EncoderProfile, sourceCodec string) bool {
+	probeCodec, ok := ffmpegCodecToProbeCodec[profile.Codec]
+	return ok && probeCodec == sourceCodec
+}
+
+// splitByChapters encodes each chapter of inputPath to its own output file,
+// named "<base> - NN - <chapter title><profile extension>", next to inputPath.
+// When the source audio is already in profile's codec, chapters are split
+// with "-c:a copy" instead of being re-encoded.
+func splitByChapters(ffmpegPath, ffprobePath, inputPath string, chapters []Chapter, profile EncoderProfile) ([]string, error) {
+	dir := filepath.Dir(inputPath)
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+
+	sourceCodec, err := probeAudioCodec(ffprobePath, inputPath)
+	useCopy := err == nil && canStreamCopy(profile, sourceCodec)
+
+	outputPaths := make([]string, 0, len(chapters))
+	for _, chapter := range chapters {
+		title := sanitizeFilename(chapter.Title)
+		if title == "" {
+			title = fmt.Sprintf("chapter-%02d", chapter.Index+1)
+		}
+
+		outputPath := filepath.Join(dir, fmt.Sprintf("%s - %02d - %s%s", baseName, chapter.Index+1, title, profile.Extension))
+
+		args := []string{
+			"-i", inputPath,
+			"-ss", strconv.FormatFloat(chapter.StartSeconds, 'f', -1, 64),
+			"-to", strconv.FormatFloat(chapter.EndSeconds, 'f', -1, 64),
+			"-vn",
+		}
+		if useCopy {
+			args = append(args, "-c:a", "copy")
+		} else {
+			args = append(args, profile.ffmpegArgs()...)
+		}
+		args = append(args, outputPath)
+
+		fmt.Printf("🔄 Splitting chapter %d/%d: %s\n", chapter.Index+1, len(chapters), title)
+
+		cmd := exec.Command(ffmpegPath, args...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return outputPaths, fmt.Errorf("ffmpeg error on chapter %d: %v", chapter.Index+1, err)
+		}
+
+		outputPaths = append(outputPaths, outputPath)
+	}
+
+	return outputPaths, nil
+}
+
+// sanitizeFilename replaces characters that are unsafe in file names across
+// common filesystems with "-" (double quotes become single quotes) and trims
+// surrounding whitespace
+func sanitizeFilename(name string) string {
+	replacer := strings.NewReplacer(
+		"/", "-", "\\", "-", ":", "-", "*", "-",
+		"?", "-", "\"", "'", "<", "-", ">", "-", "|", "-",
+	)
+	return strings.TrimSpace(replacer.Replace(name))
+}
@@ -1,25 +1,37 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/joho/godotenv"
 	"github.com/manifoldco/promptui"
+
+	"video-to-audio/downloader"
+	"video-to-audio/ffmpeg"
+	"video-to-audio/source"
 )
 
-// VideoFile represents a video file with its metadata
+// VideoFile represents a video file with its metadata. Files found inside an
+// archive have FromArchive set, ArchivePath pointing at the containing
+// archive, and EntryPath holding the entry's path inside it; Path is then a
+// synthetic, archive-adjacent path used only to name the conversion output.
 type VideoFile struct {
 	Name        string
 	Path        string
 	SizeMB      float64
 	Extension   string
+	FromArchive bool
+	ArchivePath string
+	EntryPath   string
 }
 
 // Config holds application configuration
@@ -50,58 +62,69 @@ func isURL(str string) bool {
 	return err == nil && u.Scheme != "" && u.Host != ""
 }
 
-// getVideoFiles scans directory for supported video files
-func getVideoFiles(directory string) ([]VideoFile, error) {
-	var videoFiles []VideoFile
-	supportedExts := []string{".mp4", ".avi", ".mov", ".mkv", ".flv", ".wmv", ".webm", ".m4v", ".3gp"}
-	
-	entries, err := os.ReadDir(directory)
+// getVideoFiles scans directory for supported video files, descending into
+// subdirectories when recursive is true, and transparently descends into any
+// zip/tar/tar.gz/tar.bz2 archives it finds along the way
+func getVideoFiles(directory string, recursive bool) ([]VideoFile, error) {
+	dirEntries, err := (source.DirSource{Root: directory, Recursive: recursive}).List()
 	if err != nil {
 		return nil, err
 	}
-	
-	for _, entry := range entries {
-		if entry.IsDir() {
+
+	videoFiles := make([]VideoFile, 0, len(dirEntries))
+	for _, entry := range dirEntries {
+		videoFiles = append(videoFiles, VideoFile{
+			Name:      entry.Name,
+			Path:      entry.Path,
+			SizeMB:    float64(entry.SizeBytes) / (1024 * 1024),
+			Extension: entry.Extension,
+		})
+	}
+
+	archivePaths, err := source.FindArchives(directory, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, archivePath := range archivePaths {
+		archiveEntries, err := (source.ArchiveSource{ArchivePath: archivePath}).List()
+		if err != nil {
+			fmt.Printf("⚠️  Could not read archive %s: %v\n", archivePath, err)
 			continue
 		}
-		
-		ext := strings.ToLower(filepath.Ext(entry.Name()))
-		isSupported := false
-		for _, supportedExt := range supportedExts {
-			if ext == supportedExt {
-				isSupported = true
-				break
-			}
-		}
-		
-		if isSupported {
-			fullPath := filepath.Join(directory, entry.Name())
-			info, err := entry.Info()
-			if err != nil {
-				continue
-			}
-			
-			sizeMB := float64(info.Size()) / (1024 * 1024)
-			
+
+		archiveBase := strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(filepath.Base(archivePath)))
+
+		for _, entry := range archiveEntries {
+			// Incorporate the entry's full in-archive path, not just its
+			// basename, so two entries with the same filename in different
+			// archive subdirectories don't collide on the same output path
+			entryWithoutExt := strings.TrimSuffix(entry.Path, entry.Extension)
+			flattenedEntry := sanitizeFilename(strings.ReplaceAll(entryWithoutExt, "/", " - "))
+
 			videoFiles = append(videoFiles, VideoFile{
-				Name:      entry.Name(),
-				Path:      fullPath,
-				SizeMB:    sizeMB,
-				Extension: ext,
+				Name:        fmt.Sprintf("%s/%s", filepath.Base(archivePath), entry.Name),
+				Path:        filepath.Join(filepath.Dir(archivePath), fmt.Sprintf("%s - %s%s", archiveBase, flattenedEntry, entry.Extension)),
+				SizeMB:      float64(entry.SizeBytes) / (1024 * 1024),
+				Extension:   entry.Extension,
+				FromArchive: true,
+				ArchivePath: archivePath,
+				EntryPath:   entry.Path,
 			})
 		}
 	}
-	
+
 	// Sort files alphabetically
 	sort.Slice(videoFiles, func(i, j int) bool {
 		return videoFiles[i].Name < videoFiles[j].Name
 	})
-	
+
 	return videoFiles, nil
 }
 
-// getOutputPath generates output MP3 path based on input
-func getOutputPath(inputPath string) string {
+// getOutputPath generates the output path for inputPath, using the
+// extension declared by profile
+func getOutputPath(inputPath string, profile EncoderProfile) string {
 	if isURL(inputPath) {
 		u, _ := url.Parse(inputPath)
 		baseName := filepath.Base(u.Path)
@@ -109,30 +132,135 @@ func getOutputPath(inputPath string) string {
 			baseName = "output"
 		}
 		nameWithoutExt := strings.TrimSuffix(baseName, filepath.Ext(baseName))
-		return nameWithoutExt + ".mp3"
+		return nameWithoutExt + profile.Extension
 	}
-	
+
 	dir := filepath.Dir(inputPath)
 	baseName := filepath.Base(inputPath)
 	nameWithoutExt := strings.TrimSuffix(baseName, filepath.Ext(baseName))
-	return filepath.Join(dir, nameWithoutExt+".mp3")
+	return filepath.Join(dir, nameWithoutExt+profile.Extension)
 }
 
-// convertToMP3 performs the actual conversion using ffmpeg
-func convertToMP3(inputPath, outputPath string) error {
-	fmt.Printf("🔄 Converting: %s → %s\n", filepath.Base(inputPath), filepath.Base(outputPath))
-	
-	cmd := exec.Command("ffmpeg", "-i", inputPath, "-vn", "-acodec", "libmp3lame", "-ab", "192k", outputPath)
-	
-	// Show progress by displaying stderr
-	cmd.Stderr = os.Stderr
-	
-	err := cmd.Run()
+// resolveStreamingInput fetches inputPath via the downloader when it points
+// at a known streaming site, since ffprobe/ffmpeg can't read those stream
+// URLs directly — mirroring what Converter.Convert does for plain
+// conversions. Returns inputPath unchanged otherwise. Callers should defer
+// the returned cleanup func, which removes the download's temp dir when
+// opts.KeepDownload is false.
+func resolveStreamingInput(inputPath string, opts downloader.Options) (resolved string, cleanup func(), err error) {
+	if !isURL(inputPath) || !downloader.IsStreamingURL(inputPath) {
+		return inputPath, func() {}, nil
+	}
+
+	fmt.Printf("⬇️  Fetching audio stream: %s\n", inputPath)
+	downloadedPath, err := downloader.Fetch(inputPath, opts)
 	if err != nil {
-		return fmt.Errorf("ffmpeg error: %v", err)
+		return "", func() {}, fmt.Errorf("download error: %v", err)
 	}
-	
-	return nil
+
+	cleanup = func() {}
+	if !opts.KeepDownload {
+		cleanup = func() { os.RemoveAll(filepath.Dir(downloadedPath)) }
+	}
+	return downloadedPath, cleanup, nil
+}
+
+// BatchResult captures the outcome of converting a single file in batch mode
+type BatchResult struct {
+	VideoFile  VideoFile
+	OutputPath string
+	Err        error
+}
+
+// runBatchConversion converts videoFiles in parallel using a bounded pool of
+// workers and returns one BatchResult per file
+func runBatchConversion(videoFiles []VideoFile, profile EncoderProfile, ffmpegPath string, concurrency int, downloadOpts downloader.Options) []BatchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan VideoFile)
+	results := make(chan BatchResult)
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for videoFile := range jobs {
+				outputPath := getOutputPath(videoFile.Path, profile)
+				converter := NewConverter(ffmpegPath, profile)
+				converter.OnProgress = newBatchProgressCallback(videoFile.Name)
+				converter.DownloadOpts = downloadOpts
+
+				var err error
+				if videoFile.FromArchive {
+					var reader io.ReadCloser
+					reader, err = (source.ArchiveSource{ArchivePath: videoFile.ArchivePath}).Open(videoFile.EntryPath)
+					if err == nil {
+						err = converter.ConvertStream(reader, outputPath)
+						reader.Close()
+					}
+				} else {
+					err = converter.Convert(videoFile.Path, outputPath)
+				}
+
+				results <- BatchResult{VideoFile: videoFile, OutputPath: outputPath, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, videoFile := range videoFiles {
+			jobs <- videoFile
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	batchResults := make([]BatchResult, 0, len(videoFiles))
+	for result := range results {
+		batchResults = append(batchResults, result)
+	}
+
+	return batchResults
+}
+
+// runBatchMode discovers video files under rootDir and converts them all in
+// parallel, printing a final summary
+func runBatchMode(rootDir string, recursive bool, profile EncoderProfile, ffmpegPath string, concurrency int, downloadOpts downloader.Options) {
+	fmt.Printf("🗂️  Scanning: %s (recursive: %v)\n", rootDir, recursive)
+
+	videoFiles, err := getVideoFiles(rootDir, recursive)
+	if err != nil {
+		log.Fatal("Error reading directory:", err)
+	}
+
+	if len(videoFiles) == 0 {
+		fmt.Println("❌ No supported video files found.")
+		return
+	}
+
+	fmt.Printf("📹 Found %d video file(s), converting to %s with %d worker(s)...\n\n", len(videoFiles), profile.Name, concurrency)
+
+	results := runBatchConversion(videoFiles, profile, ffmpegPath, concurrency, downloadOpts)
+
+	var succeeded, failed int
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("❌ %s: %v\n", result.VideoFile.Name, result.Err)
+		} else {
+			succeeded++
+			fmt.Printf("✅ %s → %s\n", result.VideoFile.Name, filepath.Base(result.OutputPath))
+		}
+	}
+
+	fmt.Printf("\n📊 Summary: %d succeeded, %d failed, %d total\n", succeeded, failed, len(results))
 }
 
 // getFileSize returns file size in MB
@@ -145,8 +273,49 @@ func getFileSize(path string) (float64, error) {
 }
 
 func main() {
+	batch := flag.Bool("batch", false, "run in non-interactive batch mode, converting every discovered video")
+	recursive := flag.Bool("recursive", false, "walk subdirectories when discovering video files")
+	concurrency := flag.Int("concurrency", 4, "number of files to convert in parallel in batch mode")
+	profileFlag := flag.String("profile", defaultProfileName, "encoder profile to use: "+strings.Join(profileNames(), ", "))
+	downloadCodecFlag := flag.String("download-codec", string(downloader.CodecOpus), "preferred codec when fetching a streaming URL: opus, m4a, bestaudio")
+	downloadMaxSizeMB := flag.Int("download-max-size-mb", 0, "skip streaming-site formats larger than this size in MB (0 means no limit)")
+	keepDownload := flag.Bool("keep-download", false, "keep the intermediate file downloaded from a streaming URL instead of deleting it after conversion")
+	flag.Parse()
+
+	profile, ok := builtinProfiles[*profileFlag]
+	if !ok {
+		log.Fatalf("Unknown encoder profile %q, must be one of: %s", *profileFlag, strings.Join(profileNames(), ", "))
+	}
+
+	downloadOpts := downloader.Options{
+		PreferredCodec: downloader.Codec(*downloadCodecFlag),
+		MaxFilesizeMB:  *downloadMaxSizeMB,
+		KeepDownload:   *keepDownload,
+	}
+
+	profileFlagSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "profile" {
+			profileFlagSet = true
+		}
+	})
+
 	config := loadConfig()
-	
+
+	ffmpegPath, err := ffmpeg.Resolve()
+	if err != nil {
+		log.Fatal("Error resolving ffmpeg:", err)
+	}
+
+	if *batch {
+		rootDir := config.DefaultDir
+		if args := flag.Args(); len(args) > 0 {
+			rootDir = args[0]
+		}
+		runBatchMode(rootDir, *recursive, profile, ffmpegPath, *concurrency, downloadOpts)
+		return
+	}
+
 	fmt.Println("🎵 Video to Audio Converter (Go)")
 	fmt.Println("=================================")
 	
@@ -186,13 +355,14 @@ func main() {
 	fmt.Printf("\n🗂️  Working in: %s\n", rootDir)
 	
 	// Get video files in directory
-	videoFiles, err := getVideoFiles(rootDir)
+	videoFiles, err := getVideoFiles(rootDir, *recursive)
 	if err != nil {
 		log.Fatal("Error reading directory:", err)
 	}
 	
 	var selectedFile string
-	
+	var selectedVideoFile *VideoFile
+
 	if len(videoFiles) == 0 {
 		fmt.Println("❌ No supported video files found in the directory.")
 		fmt.Println("💡 Supported formats: mp4, avi, mov, mkv, flv, wmv, webm, m4v, 3gp")
@@ -261,35 +431,151 @@ func main() {
 				log.Fatal("Error getting input:", err)
 			}
 		} else {
-			selectedFile = videoFiles[index].Name
+			selectedVideoFile = &videoFiles[index]
 		}
 	}
-	
+
 	// Determine full input path
 	var fullInputPath string
-	if isURL(selectedFile) || filepath.IsAbs(selectedFile) {
+	switch {
+	case selectedVideoFile != nil:
+		fullInputPath = selectedVideoFile.Path
+	case isURL(selectedFile) || filepath.IsAbs(selectedFile):
 		fullInputPath = selectedFile
-	} else {
+	default:
 		fullInputPath = filepath.Join(rootDir, selectedFile)
 	}
+
+	// Check if local file exists (archive entries don't exist on disk by
+	// this path — they're streamed out of the archive on demand)
+	if selectedVideoFile == nil || !selectedVideoFile.FromArchive {
+		if !isURL(fullInputPath) {
+			if _, err := os.Stat(fullInputPath); os.IsNotExist(err) {
+				fmt.Printf("❌ File not found: %s\n", fullInputPath)
+				return
+			}
+		}
+	}
 	
-	// Check if local file exists
-	if !isURL(fullInputPath) {
-		if _, err := os.Stat(fullInputPath); os.IsNotExist(err) {
-			fmt.Printf("❌ File not found: %s\n", fullInputPath)
+	// Let the user pick an encoder profile, unless one was given via --profile
+	if !profileFlagSet {
+		names := profileNames()
+		profileSelect := promptui.Select{
+			Label: "Select an output format",
+			Items: names,
+			Size:  10,
+		}
+
+		index, _, err := profileSelect.Run()
+		if err != nil {
+			log.Fatal("Error in profile selection:", err)
+		}
+		profile = builtinProfiles[names[index]]
+	}
+
+	isArchiveEntry := selectedVideoFile != nil && selectedVideoFile.FromArchive
+
+	// Offer chapter splitting and subtitle extraction as alternatives to a
+	// plain conversion. Both need a seekable file, which a file streamed out
+	// of an archive doesn't provide, so archive entries skip straight to a
+	// plain conversion.
+	actionIndex := 0
+	if !isArchiveEntry {
+		actionItems := []string{
+			"🎵 Convert entire file to audio",
+			"📚 Split output into one file per chapter",
+			"💬 Extract subtitle tracks",
+		}
+		actionSelect := promptui.Select{
+			Label: "What would you like to do",
+			Items: actionItems,
+			Size:  10,
+		}
+		var err error
+		actionIndex, _, err = actionSelect.Run()
+		if err != nil {
+			log.Fatal("Error in action selection:", err)
+		}
+	}
+
+	switch actionIndex {
+	case 1:
+		resolvedPath, cleanup, err := resolveStreamingInput(fullInputPath, downloadOpts)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
 			return
 		}
+		defer cleanup()
+
+		chapters, err := probeChapters(ffmpeg.ProbePath(ffmpegPath), resolvedPath)
+		if err != nil {
+			fmt.Printf("❌ Could not read chapters: %v\n", err)
+			return
+		}
+		if len(chapters) == 0 {
+			fmt.Println("❌ No chapters found in this file.")
+			return
+		}
+
+		outputPaths, err := splitByChapters(ffmpegPath, ffmpeg.ProbePath(ffmpegPath), resolvedPath, chapters, profile)
+		if err != nil {
+			fmt.Printf("❌ Chapter split failed: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Wrote %d chapter file(s)\n", len(outputPaths))
+		return
+	case 2:
+		resolvedPath, cleanup, err := resolveStreamingInput(fullInputPath, downloadOpts)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		defer cleanup()
+
+		streams, err := probeSubtitleStreams(ffmpeg.ProbePath(ffmpegPath), resolvedPath)
+		if err != nil {
+			fmt.Printf("❌ Could not read subtitle tracks: %v\n", err)
+			return
+		}
+		if len(streams) == 0 {
+			fmt.Println("❌ No subtitle tracks found in this file.")
+			return
+		}
+
+		outputPaths, err := extractSubtitles(ffmpegPath, resolvedPath, streams)
+		if err != nil {
+			fmt.Printf("❌ Subtitle extraction failed: %v\n", err)
+			return
+		}
+		fmt.Printf("✅ Wrote %d subtitle file(s)\n", len(outputPaths))
+		return
 	}
-	
+
 	// Generate output path
-	outputPath := getOutputPath(fullInputPath)
-	
+	outputPath := getOutputPath(fullInputPath, profile)
+
 	fmt.Printf("\n📁 Input: %s\n", fullInputPath)
 	fmt.Printf("💾 Output: %s\n", outputPath)
 	fmt.Println()
-	
+
 	// Perform conversion
-	if err := convertToMP3(fullInputPath, outputPath); err != nil {
+	converter := NewConverter(ffmpegPath, profile)
+	converter.OnProgress = newInteractiveProgressCallback()
+	converter.DownloadOpts = downloadOpts
+
+	if isArchiveEntry {
+		reader, err := (source.ArchiveSource{ArchivePath: selectedVideoFile.ArchivePath}).Open(selectedVideoFile.EntryPath)
+		if err != nil {
+			fmt.Printf("❌ Could not open archive entry: %v\n", err)
+			return
+		}
+		defer reader.Close()
+
+		if err := converter.ConvertStream(reader, outputPath); err != nil {
+			fmt.Printf("❌ Conversion failed: %v\n", err)
+			return
+		}
+	} else if err := converter.Convert(fullInputPath, outputPath); err != nil {
 		fmt.Printf("❌ Conversion failed: %v\n", err)
 		return
 	}
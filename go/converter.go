@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"video-to-audio/downloader"
+	"video-to-audio/ffmpeg"
+)
+
+// ProgressCallback is invoked as a conversion progresses. total is 0 when the
+// input's duration could not be determined (e.g. a live stream).
+type ProgressCallback func(current, total time.Duration, speed float64)
+
+// Converter encodes input files to a fixed EncoderProfile using a resolved
+// ffmpeg binary, optionally reporting progress as it runs.
+type Converter struct {
+	FFmpegPath   string
+	Profile      EncoderProfile
+	OnProgress   ProgressCallback
+	DownloadOpts downloader.Options // used when inputPath is a streaming URL
+}
+
+// NewConverter builds a Converter for the given ffmpeg binary and profile
+func NewConverter(ffmpegPath string, profile EncoderProfile) *Converter {
+	return &Converter{FFmpegPath: ffmpegPath, Profile: profile, DownloadOpts: downloader.DefaultOptions()}
+}
+
+// Convert performs the actual conversion using ffmpeg. URLs from streaming
+// sites (YouTube, Vimeo, etc.) are first fetched via the downloader package,
+// since ffmpeg cannot read their stream URLs directly; generic HTTP(S) URLs
+// and local files are passed straight to ffmpeg.
+func (c *Converter) Convert(inputPath, outputPath string) error {
+	resolvedInputPath := inputPath
+
+	if isURL(inputPath) && downloader.IsStreamingURL(inputPath) {
+		fmt.Printf("⬇️  Fetching audio stream: %s\n", inputPath)
+
+		downloadedPath, err := downloader.Fetch(inputPath, c.DownloadOpts)
+		if err != nil {
+			return fmt.Errorf("download error: %v", err)
+		}
+		resolvedInputPath = downloadedPath
+
+		if !c.DownloadOpts.KeepDownload {
+			defer os.RemoveAll(filepath.Dir(downloadedPath))
+		}
+	}
+
+	totalDuration, err := probeDuration(ffmpeg.ProbePath(c.FFmpegPath), resolvedInputPath)
+	if err != nil {
+		// Duration probing is best-effort: progress is still reported, just
+		// without a known total (e.g. live streams, exotic containers).
+		totalDuration = 0
+	}
+
+	fmt.Printf("🔄 Converting (%s): %s → %s\n", c.Profile.Name, filepath.Base(resolvedInputPath), filepath.Base(outputPath))
+
+	args := append([]string{"-i", resolvedInputPath, "-vn"}, c.Profile.ffmpegArgs()...)
+	args = append(args, "-progress", "pipe:2", "-nostats", outputPath)
+	cmd := exec.Command(c.FFmpegPath, args...)
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("ffmpeg error: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ffmpeg error: %v", err)
+	}
+
+	c.watchProgress(stderr, totalDuration)
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg error: %v", err)
+	}
+
+	return nil
+}
+
+// ConvertStream encodes r (e.g. a video streamed out of an archive entry) by
+// piping it into ffmpeg via stdin, since there is no seekable file on disk to
+// pass as -i. The total duration is unknown in this mode.
+func (c *Converter) ConvertStream(r io.Reader, outputPath string) error {
+	fmt.Printf("🔄 Converting (%s) from stream → %s\n", c.Profile.Name, filepath.Base(outputPath))
+
+	args := append([]string{"-i", "pipe:0", "-vn"}, c.Profile.ffmpegArgs()...)
+	args = append(args, "-progress", "pipe:2", "-nostats", outputPath)
+	cmd := exec.Command(c.FFmpegPath, args...)
+	cmd.Stdin = r
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("ffmpeg error: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ffmpeg error: %v", err)
+	}
+
+	c.watchProgress(stderr, 0)
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg error: %v", err)
+	}
+
+	return nil
+}
+
+// watchProgress reads ffmpeg's `-progress pipe:2` key=value stream from r,
+// invoking c.OnProgress on every "progress" key until it reports "end"
+func (c *Converter) watchProgress(r io.Reader, total time.Duration) {
+	if c.OnProgress == nil {
+		// Still need to drain the pipe so ffmpeg isn't blocked on a full buffer
+		_, _ = io.Copy(io.Discard, r)
+		return
+	}
+
+	var current time.Duration
+	var speed float64
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "out_time_ms":
+			if microseconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current = time.Duration(microseconds) * time.Microsecond
+			}
+		case "speed":
+			speed = parseSpeed(value)
+		case "progress":
+			c.OnProgress(current, total, speed)
+			if value == "end" {
+				return
+			}
+		}
+	}
+}
+
+// parseSpeed converts ffmpeg's speed field (e.g. "2.1x") to a float64 multiplier
+func parseSpeed(value string) float64 {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+	if err != nil {
+		return 0
+	}
+	return speed
+}
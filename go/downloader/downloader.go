@@ -0,0 +1,117 @@
+// Package downloader fetches audio from streaming-site URLs (YouTube, Vimeo,
+// Twitter, etc.) that ffmpeg cannot read directly, by shelling out to yt-dlp.
+package downloader
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Codec selects the audio format yt-dlp should prefer when picking a stream
+type Codec string
+
+const (
+	CodecOpus      Codec = "opus"
+	CodecM4A       Codec = "m4a"
+	CodecBestAudio Codec = "bestaudio"
+)
+
+// streamingHosts lists domains known to require yt-dlp rather than raw ffmpeg
+var streamingHosts = []string{
+	"youtube.com", "youtu.be", "vimeo.com", "twitter.com", "x.com",
+	"tiktok.com", "twitch.tv", "facebook.com", "dailymotion.com",
+}
+
+// Options controls how Fetch selects and retrieves the audio stream
+type Options struct {
+	PreferredCodec Codec
+	MaxFilesizeMB  int  // 0 means no limit
+	KeepDownload   bool // if false, caller is expected to remove the download's temp dir after use
+}
+
+// DefaultOptions returns the options used when the caller has no preference
+func DefaultOptions() Options {
+	return Options{
+		PreferredCodec: CodecOpus,
+		MaxFilesizeMB:  0,
+		KeepDownload:   false,
+	}
+}
+
+// IsStreamingURL reports whether rawURL points at a known streaming site that
+// ffmpeg cannot read directly and should instead go through yt-dlp
+func IsStreamingURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	host := strings.ToLower(strings.TrimPrefix(u.Host, "www."))
+	for _, streamingHost := range streamingHosts {
+		if host == streamingHost || strings.HasSuffix(host, "."+streamingHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSelector builds the yt-dlp -f value for the given options, preferring
+// the requested codec and falling back to the best available audio-only stream.
+// When opts.MaxFilesizeMB is set, every alternative carries the size filter,
+// so formats over the limit are never selected.
+func formatSelector(opts Options) string {
+	var alternatives []string
+	switch opts.PreferredCodec {
+	case CodecOpus:
+		alternatives = []string{"bestaudio[acodec=opus]", "bestaudio"}
+	case CodecM4A:
+		alternatives = []string{"bestaudio[ext=m4a]", "bestaudio"}
+	default:
+		alternatives = []string{"bestaudio"}
+	}
+
+	if opts.MaxFilesizeMB > 0 {
+		for i, alternative := range alternatives {
+			alternatives[i] = fmt.Sprintf("%s[filesize<%dM]", alternative, opts.MaxFilesizeMB)
+		}
+	}
+	return strings.Join(alternatives, "/")
+}
+
+// Fetch downloads the best audio-only stream for rawURL via yt-dlp into a
+// dedicated temp directory and returns the downloaded file's path. The temp
+// directory holds nothing else, so when opts.KeepDownload is false the caller
+// is expected to remove it (not just the file) via
+// os.RemoveAll(filepath.Dir(downloadedPath)).
+func Fetch(rawURL string, opts Options) (string, error) {
+	tempDir, err := os.MkdirTemp("", "video-to-audio-download-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %v", err)
+	}
+
+	outputTemplate := filepath.Join(tempDir, "%(id)s.%(ext)s")
+
+	cmd := exec.Command("yt-dlp",
+		"-f", formatSelector(opts),
+		"-o", outputTemplate,
+		"--no-playlist",
+		"--print", "after_move:filepath",
+		rawURL,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("yt-dlp error: %v", err)
+	}
+
+	downloadedPath := strings.TrimSpace(string(output))
+	if downloadedPath == "" {
+		return "", fmt.Errorf("yt-dlp did not report a downloaded file path")
+	}
+
+	return downloadedPath, nil
+}
@@ -0,0 +1,392 @@
+// Package ffmpeg locates a usable ffmpeg binary, downloading and pinning a
+// static build from BtbN/FFmpeg-Builds when one isn't already on PATH.
+package ffmpeg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	releaseBaseURL = "https://github.com/BtbN/FFmpeg-Builds/releases/download/latest"
+	pinnedVersion  = "latest"
+)
+
+// pinnedConfig is the small JSON file that remembers where we last installed
+// a pinned ffmpeg build, so subsequent runs skip the PATH/download check
+type pinnedConfig struct {
+	Path string `json:"path"`
+}
+
+// pinnedConfigPath returns the path to the pinned-config JSON file under the
+// user's cache directory
+func pinnedConfigPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "video-to-audio", "ffmpeg.json"), nil
+}
+
+// loadPinnedPath reads a previously persisted ffmpeg path, if any
+func loadPinnedPath() (string, error) {
+	path, err := pinnedConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var cfg pinnedConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", err
+	}
+	return cfg.Path, nil
+}
+
+// savePinnedPath persists the resolved ffmpeg path so future runs skip the check
+func savePinnedPath(ffmpegPath string) error {
+	configPath, err := pinnedConfigPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(pinnedConfig{Path: ffmpegPath})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0o644)
+}
+
+// ProbePath returns the ffprobe binary that ships alongside ffmpegPath, since
+// BtbN builds (and most system installs) place both binaries in the same
+// directory. Falls back to "ffprobe" on PATH if no sibling binary exists.
+func ProbePath(ffmpegPath string) string {
+	name := "ffprobe"
+	if runtime.GOOS == "windows" {
+		name = "ffprobe.exe"
+	}
+
+	sibling := filepath.Join(filepath.Dir(ffmpegPath), name)
+	if _, err := os.Stat(sibling); err == nil {
+		return sibling
+	}
+	return name
+}
+
+// Resolve returns a usable ffmpeg binary path: a previously pinned build, one
+// found on PATH, or a freshly downloaded static build, in that order.
+func Resolve() (string, error) {
+	if pinned, err := loadPinnedPath(); err == nil && pinned != "" {
+		if _, statErr := os.Stat(pinned); statErr == nil {
+			return pinned, nil
+		}
+	}
+
+	if path, err := exec.LookPath("ffmpeg"); err == nil {
+		return path, nil
+	}
+
+	fmt.Println("⚠️  ffmpeg not found on PATH, downloading a static build...")
+
+	path, err := download()
+	if err != nil {
+		return "", fmt.Errorf("downloading ffmpeg: %v", err)
+	}
+
+	if err := savePinnedPath(path); err != nil {
+		fmt.Printf("⚠️  Could not persist ffmpeg path: %v\n", err)
+	}
+
+	return path, nil
+}
+
+// platformAsset returns the BtbN release asset name for the current GOOS/GOARCH
+func platformAsset() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		switch runtime.GOARCH {
+		case "amd64":
+			return "ffmpeg-master-latest-win64-gpl.zip", nil
+		case "arm64":
+			return "ffmpeg-master-latest-winarm64-gpl.zip", nil
+		}
+	case "linux":
+		switch runtime.GOARCH {
+		case "amd64":
+			return "ffmpeg-master-latest-linux64-gpl.tar.xz", nil
+		case "arm64":
+			return "ffmpeg-master-latest-linuxarm64-gpl.tar.xz", nil
+		}
+	case "darwin":
+		switch runtime.GOARCH {
+		case "amd64":
+			return "ffmpeg-master-latest-osx64-gpl.zip", nil
+		case "arm64":
+			return "ffmpeg-master-latest-osxarm64-gpl.zip", nil
+		}
+	}
+	return "", fmt.Errorf("no BtbN/FFmpeg-Builds static build available for %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// download fetches and extracts the static ffmpeg build for this platform
+// into the user cache dir and returns the path to the ffmpeg binary
+func download() (string, error) {
+	asset, err := platformAsset()
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	installDir := filepath.Join(cacheDir, "video-to-audio", "ffmpeg", pinnedVersion)
+	if err := os.MkdirAll(installDir, 0o755); err != nil {
+		return "", err
+	}
+
+	archivePath := filepath.Join(os.TempDir(), asset)
+	archiveURL := releaseBaseURL + "/" + asset
+	if err := downloadFile(archiveURL, archivePath); err != nil {
+		return "", err
+	}
+	defer os.Remove(archivePath)
+
+	if err := verifyChecksum(archivePath, archiveURL+".sha256"); err != nil {
+		return "", err
+	}
+
+	if err := extractArchive(archivePath, installDir); err != nil {
+		return "", err
+	}
+
+	binaryName := "ffmpeg"
+	if runtime.GOOS == "windows" {
+		binaryName = "ffmpeg.exe"
+	}
+	return findBinary(installDir, binaryName)
+}
+
+// downloadFile streams url to destPath
+func downloadFile(url, destPath string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s fetching %s", resp.Status, url)
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// verifyChecksum downloads the .sha256 sidecar file published alongside the
+// release asset and confirms it matches the downloaded archive
+func verifyChecksum(archivePath, checksumURL string) error {
+	resp, err := http.Get(checksumURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s fetching checksum", resp.Status)
+	}
+
+	checksumBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	expected := strings.ToLower(strings.Fields(string(checksumBody))[0])
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(hasher.Sum(nil))
+
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// safeJoin joins destDir and entryName, rejecting entries that would
+// escape destDir (zip-slip) via ".." path segments or an absolute path
+func safeJoin(destDir, entryName string) (string, error) {
+	destPath := filepath.Join(destDir, entryName)
+
+	destDirWithSep := filepath.Clean(destDir) + string(os.PathSeparator)
+	if !strings.HasPrefix(destPath, destDirWithSep) && filepath.Clean(destPath) != filepath.Clean(destDir) {
+		return "", fmt.Errorf("archive entry %q escapes destination directory", entryName)
+	}
+
+	return destPath, nil
+}
+
+// extractArchive extracts a .zip or .tar.xz archive into destDir
+func extractArchive(archivePath, destDir string) error {
+	if strings.HasSuffix(archivePath, ".zip") {
+		return extractZip(archivePath, destDir)
+	}
+	return extractTarXz(archivePath, destDir)
+}
+
+func extractZip(archivePath, destDir string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if err := extractZipEntry(f, destDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipEntry(f *zip.File, destDir string) error {
+	destPath, err := safeJoin(destDir, f.Name)
+	if err != nil {
+		return err
+	}
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(destPath, 0o755)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func extractTarXz(archivePath, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	xzReader, err := xz.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	tarReader := tar.NewReader(xzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		destPath, err := safeJoin(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(destPath, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tarReader); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		}
+	}
+	return nil
+}
+
+// findBinary walks dir looking for a file named binaryName
+func findBinary(dir, binaryName string) (string, error) {
+	var found string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == binaryName {
+			found = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("%s not found in extracted archive", binaryName)
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(found, 0o755); err != nil {
+			return "", err
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// ffprobeFormat mirrors the subset of `ffprobe -show_format -of json` output we need
+type ffprobeFormat struct {
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+// probeDuration shells out to ffprobe to determine the duration of inputPath.
+// Returns 0 when the duration can't be determined (e.g. a live stream),
+// which callers treat as "unknown total" rather than a fatal error.
+func probeDuration(ffprobePath, inputPath string) (time.Duration, error) {
+	cmd := exec.Command(ffprobePath, "-v", "error", "-show_format", "-show_streams", "-of", "json", inputPath)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe error: %v", err)
+	}
+
+	var parsed ffprobeFormat
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return 0, fmt.Errorf("parsing ffprobe output: %v", err)
+	}
+
+	seconds, err := strconv.ParseFloat(parsed.Format.Duration, 64)
+	if err != nil {
+		return 0, nil
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ffprobeAudioStream mirrors the subset of `ffprobe -show_entries
+// stream=codec_name` output we need
+type ffprobeAudioStream struct {
+	Streams []struct {
+		CodecName string `json:"codec_name"`
+	} `json:"streams"`
+}
+
+// probeAudioCodec returns the codec name of inputPath's first audio stream,
+// e.g. "aac" or "mp3", so callers can decide whether a stream copy is possible
+func probeAudioCodec(ffprobePath, inputPath string) (string, error) {
+	cmd := exec.Command(ffprobePath, "-v", "error", "-select_streams", "a:0", "-show_entries", "stream=codec_name", "-of", "json", inputPath)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ffprobe error: %v", err)
+	}
+
+	var parsed ffprobeAudioStream
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return "", fmt.Errorf("parsing ffprobe output: %v", err)
+	}
+	if len(parsed.Streams) == 0 {
+		return "", fmt.Errorf("no audio stream found in %s", inputPath)
+	}
+
+	return parsed.Streams[0].CodecName, nil
+}
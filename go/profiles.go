@@ -0,0 +1,85 @@
+package main
+
+import "fmt"
+
+// EncoderProfile describes a target audio format: which ffmpeg codec to use,
+// its bitrate/sample rate/channel settings, and the output container extension
+type EncoderProfile struct {
+	Name         string
+	Codec        string // ffmpeg -acodec value
+	BitrateKbps  int    // 0 means the codec has no bitrate setting (e.g. flac, wav)
+	SampleRateHz int    // 0 means keep the source sample rate
+	Channels     int    // 0 means keep the source channel count
+	Extension    string // output file extension, including the leading dot
+}
+
+// builtinProfiles are the encoder profiles offered out of the box
+var builtinProfiles = map[string]EncoderProfile{
+	"mp3-192k": {
+		Name:        "mp3-192k",
+		Codec:       "libmp3lame",
+		BitrateKbps: 192,
+		Extension:   ".mp3",
+	},
+	"mp3-320k": {
+		Name:        "mp3-320k",
+		Codec:       "libmp3lame",
+		BitrateKbps: 320,
+		Extension:   ".mp3",
+	},
+	"opus-96k": {
+		Name:        "opus-96k",
+		Codec:       "libopus",
+		BitrateKbps: 96,
+		Extension:   ".opus",
+	},
+	"aac-128k": {
+		Name:        "aac-128k",
+		Codec:       "aac",
+		BitrateKbps: 128,
+		Extension:   ".m4a",
+	},
+	"flac": {
+		Name:      "flac",
+		Codec:     "flac",
+		Extension: ".flac",
+	},
+	"wav-pcm16": {
+		Name:      "wav-pcm16",
+		Codec:     "pcm_s16le",
+		Extension: ".wav",
+	},
+	"amr-nb": {
+		Name:         "amr-nb",
+		Codec:        "libopencore_amrnb",
+		BitrateKbps:  12,
+		SampleRateHz: 8000,
+		Channels:     1,
+		Extension:    ".amr",
+	},
+}
+
+// defaultProfileName is used when the user doesn't pick a profile
+const defaultProfileName = "mp3-192k"
+
+// profileNames returns the built-in profile names in a stable display order
+func profileNames() []string {
+	return []string{"mp3-192k", "mp3-320k", "opus-96k", "aac-128k", "flac", "wav-pcm16", "amr-nb"}
+}
+
+// ffmpegArgs builds the codec-related ffmpeg flags for this profile
+func (p EncoderProfile) ffmpegArgs() []string {
+	args := []string{"-acodec", p.Codec}
+
+	if p.BitrateKbps > 0 {
+		args = append(args, "-ab", fmt.Sprintf("%dk", p.BitrateKbps))
+	}
+	if p.SampleRateHz > 0 {
+		args = append(args, "-ar", fmt.Sprintf("%d", p.SampleRateHz))
+	}
+	if p.Channels > 0 {
+		args = append(args, "-ac", fmt.Sprintf("%d", p.Channels))
+	}
+
+	return args
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// newInteractiveProgressCallback drives a single live progress bar, showing
+// percentage, ETA, and current encode speed
+func newInteractiveProgressCallback() ProgressCallback {
+	var bar *progressbar.ProgressBar
+
+	return func(current, total time.Duration, speed float64) {
+		if bar == nil {
+			max := int64(-1)
+			if total > 0 {
+				max = total.Milliseconds()
+			}
+			bar = progressbar.NewOptions64(max,
+				progressbar.OptionSetDescription("encoding"),
+				progressbar.OptionShowCount(),
+				progressbar.OptionSetWidth(30),
+				progressbar.OptionSetPredictTime(true),
+			)
+		}
+
+		bar.Describe(fmt.Sprintf("encoding (%.1fx)", speed))
+		_ = bar.Set64(current.Milliseconds())
+	}
+}
+
+// newBatchProgressCallback reports a given file's progress as occasional log
+// lines rather than an in-place bar, since batch mode runs many workers
+// concurrently writing to the same terminal
+func newBatchProgressCallback(name string) ProgressCallback {
+	lastReportedPercent := -10
+
+	return func(current, total time.Duration, speed float64) {
+		if total <= 0 {
+			return
+		}
+
+		percent := int(current.Seconds() / total.Seconds() * 100)
+		if percent < lastReportedPercent+10 {
+			return
+		}
+		lastReportedPercent = percent
+
+		fmt.Printf("   ⏳ %s: %d%% (%.1fx)\n", name, percent, speed)
+	}
+}
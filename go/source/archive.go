@@ -0,0 +1,201 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ArchiveSource lists and opens video files inside a single zip/tar/tar.gz/tar.bz2 archive
+type ArchiveSource struct {
+	ArchivePath string
+}
+
+// List returns every supported video file inside the archive. Entry.Path is
+// the entry's internal path, which Open expects back unchanged.
+func (s ArchiveSource) List() ([]Entry, error) {
+	if strings.HasSuffix(strings.ToLower(s.ArchivePath), ".zip") {
+		return s.listZip()
+	}
+	return s.listTar()
+}
+
+// Open streams a single entry out of the archive by its internal path
+func (s ArchiveSource) Open(entryPath string) (io.ReadCloser, error) {
+	if strings.HasSuffix(strings.ToLower(s.ArchivePath), ".zip") {
+		return s.openZip(entryPath)
+	}
+	return s.openTar(entryPath)
+}
+
+func (s ArchiveSource) listZip() ([]Entry, error) {
+	r, err := zip.OpenReader(s.ArchivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []Entry
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(f.Name))
+		if !IsSupportedVideoExt(ext) {
+			continue
+		}
+		entries = append(entries, Entry{
+			Name:      f.Name,
+			Path:      f.Name,
+			SizeBytes: int64(f.UncompressedSize64),
+			Extension: ext,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func (s ArchiveSource) openZip(entryPath string) (io.ReadCloser, error) {
+	r, err := zip.OpenReader(s.ArchivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range r.File {
+		if f.Name != entryPath {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		return &closeBoth{ReadCloser: rc, outer: r}, nil
+	}
+
+	r.Close()
+	return nil, fmt.Errorf("entry %q not found in %s", entryPath, s.ArchivePath)
+}
+
+// tarReaderFor opens the archive file and wraps it with the decompressor
+// appropriate for its extension, returning a plain (uncompressed) tar stream
+func (s ArchiveSource) tarReaderFor() (*os.File, io.Reader, error) {
+	f, err := os.Open(s.ArchivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lower := strings.ToLower(s.ArchivePath)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return f, gz, nil
+	case strings.HasSuffix(lower, ".tar.bz2"), strings.HasSuffix(lower, ".tbz2"):
+		return f, bzip2.NewReader(f), nil
+	default:
+		return f, f, nil
+	}
+}
+
+func (s ArchiveSource) listTar() ([]Entry, error) {
+	f, r, err := s.tarReaderFor()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(header.Name))
+		if !IsSupportedVideoExt(ext) {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			Name:      header.Name,
+			Path:      header.Name,
+			SizeBytes: header.Size,
+			Extension: ext,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func (s ArchiveSource) openTar(entryPath string) (io.ReadCloser, error) {
+	f, r, err := s.tarReaderFor()
+	if err != nil {
+		return nil, err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if header.Name != entryPath {
+			continue
+		}
+		return &tarEntryReader{tarReader: tr, file: f}, nil
+	}
+
+	f.Close()
+	return nil, fmt.Errorf("entry %q not found in %s", entryPath, s.ArchivePath)
+}
+
+// closeBoth closes an archive member's reader along with the zip.ReadCloser
+// that opened it
+type closeBoth struct {
+	io.ReadCloser
+	outer *zip.ReadCloser
+}
+
+func (c *closeBoth) Close() error {
+	_ = c.ReadCloser.Close()
+	return c.outer.Close()
+}
+
+// tarEntryReader streams the current tar entry and closes the underlying
+// archive file once done
+type tarEntryReader struct {
+	tarReader *tar.Reader
+	file      *os.File
+}
+
+func (t *tarEntryReader) Read(p []byte) (int, error) {
+	return t.tarReader.Read(p)
+}
+
+func (t *tarEntryReader) Close() error {
+	return t.file.Close()
+}
@@ -0,0 +1,152 @@
+// Package source abstracts over where video files come from: a plain
+// filesystem directory, or an archive (zip/tar/tar.gz/tar.bz2) that needs to
+// be descended into transparently.
+package source
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SupportedVideoExts are the video file extensions a Source will surface
+var SupportedVideoExts = []string{".mp4", ".avi", ".mov", ".mkv", ".flv", ".wmv", ".webm", ".m4v", ".3gp"}
+
+// IsSupportedVideoExt reports whether ext (lowercase, with leading dot) is a
+// supported video extension
+func IsSupportedVideoExt(ext string) bool {
+	for _, supportedExt := range SupportedVideoExts {
+		if ext == supportedExt {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveExts are the archive extensions a Source will descend into
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2"}
+
+// IsArchivePath reports whether path names a supported archive
+func IsArchivePath(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entry is one video file found by a Source, either on disk or inside an archive
+type Entry struct {
+	Name      string // display name
+	Path      string // opaque identifier passed back to Source.Open
+	SizeBytes int64
+	Extension string
+}
+
+// Source lists and opens video files from some underlying location
+type Source interface {
+	List() ([]Entry, error)
+	Open(path string) (io.ReadCloser, error)
+}
+
+// walkFiles visits every file directly under root, or under all of its
+// subdirectories when recursive is set, calling visit(fullPath, name, size)
+func walkFiles(root string, recursive bool, visit func(path, name string, size int64)) error {
+	if recursive {
+		return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			visit(path, d.Name(), info.Size())
+			return nil
+		})
+	}
+
+	dirEntries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirEntries {
+		if d.IsDir() {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		visit(filepath.Join(root, d.Name()), d.Name(), info.Size())
+	}
+	return nil
+}
+
+// DirSource lists supported video files directly under a filesystem directory,
+// or under all of its subdirectories when Recursive is set
+type DirSource struct {
+	Root      string
+	Recursive bool
+}
+
+// List returns every supported video file under the directory, walking
+// subdirectories when Recursive is set
+func (s DirSource) List() ([]Entry, error) {
+	var entries []Entry
+
+	err := walkFiles(s.Root, s.Recursive, func(path, name string, size int64) {
+		ext := strings.ToLower(filepath.Ext(name))
+		if !IsSupportedVideoExt(ext) {
+			return
+		}
+
+		displayName := name
+		if s.Recursive {
+			// Disambiguate same-named files from different subdirectories
+			// (e.g. a/clip.mp4 vs b/clip.mp4), which otherwise render
+			// identically in the interactive picker
+			if rel, err := filepath.Rel(s.Root, path); err == nil {
+				displayName = rel
+			}
+		}
+
+		entries = append(entries, Entry{Name: displayName, Path: path, SizeBytes: size, Extension: ext})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Open returns the contents of the file at path (as returned by List)
+func (s DirSource) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// FindArchives returns the path of every supported archive found directly
+// under root, or under all of its subdirectories when recursive is set
+func FindArchives(root string, recursive bool) ([]string, error) {
+	var archivePaths []string
+
+	err := walkFiles(root, recursive, func(path, name string, size int64) {
+		if IsArchivePath(name) {
+			archivePaths = append(archivePaths, path)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(archivePaths)
+	return archivePaths, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/asticode/go-astisub"
+)
+
+// SubtitleStream is one subtitle track embedded in a video, as reported by ffprobe
+type SubtitleStream struct {
+	RelativeIndex int // position among subtitle streams, for ffmpeg's -map 0:s:N
+	CodecName     string
+	Language      string
+}
+
+// ffprobeSubtitleStreams mirrors `ffprobe -show_streams -select_streams s -of json` output
+type ffprobeSubtitleStreams struct {
+	Streams []struct {
+		CodecName string `json:"codec_name"`
+		Tags      struct {
+			Language string `json:"language"`
+		} `json:"tags"`
+	} `json:"streams"`
+}
+
+// probeSubtitleStreams lists the subtitle tracks embedded in inputPath, if any
+func probeSubtitleStreams(ffprobePath, inputPath string) ([]SubtitleStream, error) {
+	cmd := exec.Command(ffprobePath, "-v", "error", "-select_streams", "s", "-show_streams", "-of", "json", inputPath)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %v", err)
+	}
+
+	var parsed ffprobeSubtitleStreams
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing ffprobe output: %v", err)
+	}
+
+	streams := make([]SubtitleStream, 0, len(parsed.Streams))
+	for i, s := range parsed.Streams {
+		language := s.Tags.Language
+		if language == "" {
+			language = fmt.Sprintf("track%d", i+1)
+		}
+		streams = append(streams, SubtitleStream{
+			RelativeIndex: i,
+			CodecName:     s.CodecName,
+			Language:      language,
+		})
+	}
+
+	return streams, nil
+}
+
+// extractSubtitles pulls each subtitle stream out of inputPath via ffmpeg,
+// then normalizes it to SRT with go-astisub, writing "<base>.<language>.srt"
+// files next to inputPath. When multiple streams share a language (e.g. a
+// full track and a forced/SDH track both tagged "eng"), the stream's
+// RelativeIndex is appended to keep their file names distinct.
+func extractSubtitles(ffmpegPath, inputPath string, streams []SubtitleStream) ([]string, error) {
+	dir := filepath.Dir(inputPath)
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+
+	languageCounts := make(map[string]int, len(streams))
+	for _, stream := range streams {
+		languageCounts[stream.Language]++
+	}
+
+	outputPaths := make([]string, 0, len(streams))
+	for _, stream := range streams {
+		label := stream.Language
+		if languageCounts[stream.Language] > 1 {
+			label = fmt.Sprintf("%s.%d", stream.Language, stream.RelativeIndex)
+		}
+
+		rawPath := filepath.Join(dir, fmt.Sprintf("%s.%s.raw.srt", baseName, label))
+
+		cmd := exec.Command(ffmpegPath,
+			"-i", inputPath,
+			"-map", fmt.Sprintf("0:s:%d", stream.RelativeIndex),
+			"-c:s", "srt",
+			rawPath,
+		)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return outputPaths, fmt.Errorf("ffmpeg error extracting subtitle track %s: %v", stream.Language, err)
+		}
+		defer os.Remove(rawPath)
+
+		subs, err := astisub.OpenFile(rawPath)
+		if err != nil {
+			return outputPaths, fmt.Errorf("parsing subtitle track %s: %v", stream.Language, err)
+		}
+
+		outputPath := filepath.Join(dir, fmt.Sprintf("%s.%s.srt", baseName, label))
+		if err := subs.Write(outputPath); err != nil {
+			return outputPaths, fmt.Errorf("writing subtitle track %s: %v", stream.Language, err)
+		}
+
+		outputPaths = append(outputPaths, outputPath)
+	}
+
+	return outputPaths, nil
+}